@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+// WaitForShutdown blocks until SIGINT or SIGTERM is received, then calls
+// cancel to begin a graceful shutdown. Binary entry points (e.g. the rollup
+// relayer's main) should run this in its own goroutine and use cancel to
+// stop their long-running components, such as BatchProposer.Stop. A wedged
+// shutdown, for example a DB call that never returns, can't block the
+// process forever: a third signal terminates unconditionally.
+func WaitForShutdown(cancel func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	var count int
+	for range sigCh {
+		count++
+		switch {
+		case count == 1:
+			log.Info("received shutdown signal, starting graceful shutdown")
+			cancel()
+		case count >= 3:
+			log.Crit("received shutdown signal a third time, terminating immediately")
+		default:
+			log.Warn("received shutdown signal again, still shutting down gracefully", "count", count, "forceExitAt", 3)
+		}
+	}
+}
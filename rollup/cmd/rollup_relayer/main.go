@@ -0,0 +1,183 @@
+// Command rollup_relayer runs the batch proposer against the configured L2
+// DB, proposing a new batch on a fixed interval until SIGINT/SIGTERM
+// triggers a graceful shutdown. Run `rollup_relayer replay <fromBatchIndex>
+// <toBatchIndex>` to regression-test chunk selection against already
+// committed batches instead of proposing new ones.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/ethclient"
+	"github.com/scroll-tech/go-ethereum/log"
+	"github.com/scroll-tech/go-ethereum/params"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"scroll-tech/common/utils"
+
+	"scroll-tech/rollup/internal/config"
+	"scroll-tech/rollup/internal/controller/watcher"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Crit("rollup_relayer replay failed", "err", err)
+		}
+		return
+	}
+	if err := run(os.Args[1:]); err != nil {
+		log.Crit("rollup_relayer failed", "err", err)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("rollup_relayer", flag.ExitOnError)
+	dsn := fs.String("dsn", "", "L2 DB DSN")
+	l1RPC := fs.String("l1-rpc", "", "L1 RPC endpoint, required when dynamic_sizing is enabled")
+	configPath := fs.String("config", "./config.json", "path to batch proposer config")
+	chainConfigPath := fs.String("chain-config", "./genesis.json", "path to L2 chain config")
+	proposeIntervalSec := fs.Uint64("propose-interval-sec", 10, "how often to try proposing a batch")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadBatchProposerConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load batch proposer config: %w", err)
+	}
+	chainCfg, err := loadChainConfig(*chainConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chain config: %w", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(*dsn), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+
+	l1FeeOracle, err := newL1FeeOracle(cfg, *l1RPC)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	batchProposer := watcher.NewBatchProposer(ctx, cfg, chainCfg, db, l1FeeOracle, nil)
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(*proposeIntervalSec) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				batchProposer.TryProposeBatch()
+			}
+		}
+	}()
+
+	go utils.WaitForShutdown(cancel)
+	<-ctx.Done()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	return batchProposer.Stop(shutdownCtx)
+}
+
+// runReplay re-runs chunk selection against every already-committed batch in
+// [fromBatchIndex, toBatchIndex] and reports any batch whose chunk range the
+// proposer would no longer reproduce, see BatchProposer.Replay. It exits with
+// a non-zero status if any mismatch is found.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("rollup_relayer replay", flag.ExitOnError)
+	dsn := fs.String("dsn", "", "L2 DB DSN")
+	configPath := fs.String("config", "./config.json", "path to batch proposer config")
+	chainConfigPath := fs.String("chain-config", "./genesis.json", "path to L2 chain config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: rollup_relayer replay [flags] <fromBatchIndex> <toBatchIndex>")
+	}
+	fromIndex, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid fromBatchIndex: %w", err)
+	}
+	toIndex, err := strconv.ParseUint(fs.Arg(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid toBatchIndex: %w", err)
+	}
+
+	cfg, err := loadBatchProposerConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load batch proposer config: %w", err)
+	}
+	chainCfg, err := loadChainConfig(*chainConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chain config: %w", err)
+	}
+	db, err := gorm.Open(postgres.Open(*dsn), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+
+	batchProposer := watcher.NewBatchProposer(context.Background(), cfg, chainCfg, db, nil, nil)
+	report, err := batchProposer.Replay(context.Background(), fromIndex, toIndex)
+	if err != nil {
+		return fmt.Errorf("replay failed: %w", err)
+	}
+	if len(report.Mismatches) > 0 {
+		return fmt.Errorf("replay found %d mismatch(es) out of %d batch(es) checked", len(report.Mismatches), report.BatchesChecked)
+	}
+	return nil
+}
+
+// newL1FeeOracle dials l1RPC and wraps it in an L1FeeOracle when dynamic
+// sizing is enabled, so NewBatchProposer can scale batch limits to observed
+// L1 fee conditions; it returns a nil oracle, which NewBatchProposer treats
+// as "use the static limits", when dynamic sizing is off.
+func newL1FeeOracle(cfg *config.BatchProposerConfig, l1RPC string) (watcher.L1FeeOracle, error) {
+	if !cfg.DynamicSizing {
+		return nil, nil
+	}
+	if l1RPC == "" {
+		return nil, fmt.Errorf("-l1-rpc is required when dynamic_sizing is enabled")
+	}
+	client, err := ethclient.Dial(l1RPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial L1 RPC: %w", err)
+	}
+	return watcher.NewEthClientL1FeeOracle(client), nil
+}
+
+func loadBatchProposerConfig(path string) (*config.BatchProposerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config.BatchProposerConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadChainConfig(path string) (*params.ChainConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	chainCfg := &params.ChainConfig{}
+	if err := json.Unmarshal(data, chainCfg); err != nil {
+		return nil, err
+	}
+	return chainCfg, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+// BatchProposerConfig loads batch_proposer_config.json config.
+type BatchProposerConfig struct {
+	MaxChunkNumPerBatch             uint64  `json:"max_chunk_num_per_batch"`
+	MaxL1CommitGasPerBatch          uint64  `json:"max_l1_commit_gas_per_batch"`
+	MaxL1CommitCalldataSizePerBatch uint64  `json:"max_l1_commit_calldata_size_per_batch"`
+	BatchTimeoutSec                 uint64  `json:"batch_timeout_sec"`
+	GasCostIncreaseMultiplier       float64 `json:"gas_cost_increase_multiplier"`
+
+	// ChunkSelectionMode selects the algorithm used to pick the prefix of
+	// candidate chunks included in a batch: "greedy" (default) or "maxfill".
+	ChunkSelectionMode string `json:"chunk_selection_mode,omitempty"`
+
+	// BatchDebugDir, when set, enables writing a JSON trace file for every
+	// proposed batch under this directory. BatchDebugMaxFiles bounds how many
+	// trace files are kept before the oldest are pruned.
+	BatchDebugDir      string `json:"batch_debug_dir,omitempty"`
+	BatchDebugMaxFiles int    `json:"batch_debug_max_files,omitempty"`
+
+	// DynamicSizing enables the PI-controller batch sizing policy, which
+	// scales MaxChunkNumPerBatch, MaxL1CommitGasPerBatch and the blob size
+	// limit down towards observed L1 fee conditions instead of always using
+	// the static configured limits. MaxL1CommitCalldataSizePerBatch is not
+	// scaled. It has no effect unless
+	// NewBatchProposer is also given a non-nil L1FeeOracle.
+	DynamicSizing bool `json:"dynamic_sizing,omitempty"`
+	// TargetCostPerByte is the observed L1 cost-per-byte the PI controller
+	// steers towards, in wei.
+	TargetCostPerByte float64 `json:"target_cost_per_byte,omitempty"`
+	// SizingKP and SizingKI are the proportional and integral gains of the PI
+	// controller.
+	SizingKP float64 `json:"sizing_kp,omitempty"`
+	SizingKI float64 `json:"sizing_ki,omitempty"`
+	// SizingMinScale floors the scale factor the PI controller may apply to
+	// the static limits, preventing it from collapsing batches to nothing
+	// when L1 fees spike.
+	SizingMinScale float64 `json:"sizing_min_scale,omitempty"`
+}
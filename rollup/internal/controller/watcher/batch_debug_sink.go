@@ -0,0 +1,122 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+// prefixMetricsSample is one point in the per-prefix-length metrics series
+// recorded while selecting chunks for a batch.
+type prefixMetricsSample struct {
+	NumChunks            uint64 `json:"numChunks"`
+	L1CommitCalldataSize uint64 `json:"l1CommitCalldataSize"`
+	L1CommitGas          uint64 `json:"l1CommitGas"`
+	L1CommitBlobSize     uint64 `json:"l1CommitBlobSize"`
+}
+
+// batchTrace is the JSON document BatchDebugSink writes for a single
+// proposeBatch call that produced a batch.
+type batchTrace struct {
+	BatchIndex              uint64                `json:"batchIndex"`
+	StartChunkIndex         uint64                `json:"startChunkIndex"`
+	EndChunkIndex           uint64                `json:"endChunkIndex"`
+	CandidateChunkCount     int                   `json:"candidateChunkCount"`
+	ForkBoundaryTruncatedAt uint64                `json:"forkBoundaryTruncatedAt,omitempty"`
+	PrefixMetrics           []prefixMetricsSample `json:"prefixMetrics"`
+	LimitHit                bool                  `json:"limitHit"`
+	TimeoutReached          bool                  `json:"timeoutReached"`
+	FirstBlockTimestamp     uint64                `json:"firstBlockTimestamp"`
+	ParentBatchHash         string                `json:"parentBatchHash"`
+	CodecVersion            string                `json:"codecVersion"`
+	GetDAChunksMillis       int64                 `json:"getDAChunksMillis"`
+	CalculateMetricsMillis  int64                 `json:"calculateMetricsMillis"`
+}
+
+// BatchDebugSink persists a batchTrace for every proposed batch when
+// enabled, mirroring the chunk-proposer's batch-debug trace pattern.
+type BatchDebugSink struct {
+	dir      string
+	maxFiles int
+}
+
+// NewBatchDebugSink returns a BatchDebugSink writing trace files under dir,
+// or nil if dir is empty, in which case debug tracing is disabled. maxFiles
+// bounds how many trace files are kept on disk; once exceeded, the oldest
+// files are pruned. maxFiles <= 0 disables rotation.
+func NewBatchDebugSink(dir string, maxFiles int) *BatchDebugSink {
+	if dir == "" {
+		return nil
+	}
+	return &BatchDebugSink{dir: dir, maxFiles: maxFiles}
+}
+
+// write marshals trace to JSON and writes it to
+// batch-<index>-<startChunk>-<endChunk>.json under s.dir, then rotates old
+// files. s may be nil, in which case write is a no-op.
+func (s *BatchDebugSink) write(trace *batchTrace) {
+	if s == nil {
+		return
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		log.Warn("BatchDebugSink: failed to create batch debug dir", "dir", s.dir, "err", err)
+		return
+	}
+
+	name := fmt.Sprintf("batch-%d-%d-%d.json", trace.BatchIndex, trace.StartChunkIndex, trace.EndChunkIndex)
+	path := filepath.Join(s.dir, name)
+	data, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		log.Warn("BatchDebugSink: failed to marshal batch trace", "err", err)
+		return
+	}
+	if err = os.WriteFile(path, data, 0o644); err != nil {
+		log.Warn("BatchDebugSink: failed to write batch trace", "path", path, "err", err)
+		return
+	}
+
+	s.rotate()
+}
+
+// rotate removes the oldest trace files once more than s.maxFiles exist.
+func (s *BatchDebugSink) rotate() {
+	if s.maxFiles <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Warn("BatchDebugSink: failed to list batch debug dir", "dir", s.dir, "err", err)
+		return
+	}
+
+	type fileInfo struct {
+		name    string
+		modTime int64
+	}
+	var files []fileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "batch-") || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			continue
+		}
+		files = append(files, fileInfo{name: entry.Name(), modTime: info.ModTime().UnixNano()})
+	}
+	if len(files) <= s.maxFiles {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime < files[j].modTime })
+	for _, f := range files[:len(files)-s.maxFiles] {
+		if err := os.Remove(filepath.Join(s.dir, f.name)); err != nil {
+			log.Warn("BatchDebugSink: failed to prune old batch trace", "name", f.name, "err", err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -15,8 +16,6 @@ import (
 
 	"scroll-tech/common/forks"
 	"scroll-tech/common/types/encoding"
-	"scroll-tech/common/types/encoding/codecv0"
-	"scroll-tech/common/types/encoding/codecv1"
 
 	"scroll-tech/rollup/internal/config"
 	"scroll-tech/rollup/internal/orm"
@@ -24,8 +23,14 @@ import (
 
 // BatchProposer proposes batches based on available unbatched chunks.
 type BatchProposer struct {
-	ctx context.Context
-	db  *gorm.DB
+	ctx    context.Context
+	cancel context.CancelFunc
+	db     *gorm.DB
+
+	// mu gates TryProposeBatch re-entrancy: a non-blocking TryLock ensures a
+	// slow-running proposal can't overlap with the next periodic invocation,
+	// and lets Stop wait for an in-flight proposal to finish cleanly.
+	mu sync.Mutex
 
 	batchOrm   *orm.Batch
 	chunkOrm   *orm.Chunk
@@ -37,7 +42,12 @@ type BatchProposer struct {
 	batchTimeoutSec                 uint64
 	gasCostIncreaseMultiplier       float64
 	forkMap                         map[uint64]bool
-	banachForkHeight                uint64
+
+	sizingPolicy       BatchSizingPolicy
+	staticLimits       BatchSizeLimits
+	codecRegistry      *CodecRegistry
+	chunkSelectionMode chunkSelectionMode
+	debugSink          *BatchDebugSink
 
 	batchProposerCircleTotal           prometheus.Counter
 	proposeBatchFailureTotal           prometheus.Counter
@@ -49,8 +59,32 @@ type BatchProposer struct {
 	batchChunksNum                     prometheus.Gauge
 	batchFirstBlockTimeoutReached      prometheus.Counter
 	batchChunksProposeNotEnoughTotal   prometheus.Counter
+	effectiveMaxChunkNumPerBatch       prometheus.Gauge
+	effectiveMaxL1CommitGasPerBatch    prometheus.Gauge
+	effectiveMaxBlobSize               prometheus.Gauge
+	unusedBlobBytes                    prometheus.Gauge
+	proposeBatchSkippedBusyTotal       prometheus.Counter
 }
 
+// chunkSelectionMode controls how proposeBatch picks the prefix of
+// candidate chunks to include in a batch.
+type chunkSelectionMode string
+
+const (
+	// chunkSelectionGreedy appends candidate chunks one at a time and stops
+	// as soon as a limit would be exceeded. This is today's behavior; it can
+	// leave substantial blob/calldata headroom unused near fork boundaries.
+	chunkSelectionGreedy chunkSelectionMode = "greedy"
+	// chunkSelectionMaxFill evaluates candidate prefix lengths from the
+	// longest down and picks the longest one that still fits all limits.
+	chunkSelectionMaxFill chunkSelectionMode = "maxfill"
+
+	// defaultBatchDebugMaxFiles bounds batch debug trace files when
+	// cfg.BatchDebugDir is set but cfg.BatchDebugMaxFiles is left at its zero
+	// value, so enabling tracing doesn't silently fill up disk.
+	defaultBatchDebugMaxFiles = 1000
+)
+
 type batchMetrics struct {
 	// common metrics
 	numChunks           uint64
@@ -64,8 +98,11 @@ type batchMetrics struct {
 	l1CommitBlobSize uint64
 }
 
-// NewBatchProposer creates a new BatchProposer instance.
-func NewBatchProposer(ctx context.Context, cfg *config.BatchProposerConfig, chainCfg *params.ChainConfig, db *gorm.DB, reg prometheus.Registerer) *BatchProposer {
+// NewBatchProposer creates a new BatchProposer instance. l1FeeOracle may be
+// nil, in which case the proposer falls back to the static limits in cfg;
+// when non-nil and cfg.DynamicSizing is enabled, limits are instead adapted
+// to observed L1 fee conditions, see BatchSizingPolicy.
+func NewBatchProposer(ctx context.Context, cfg *config.BatchProposerConfig, chainCfg *params.ChainConfig, db *gorm.DB, l1FeeOracle L1FeeOracle, reg prometheus.Registerer) *BatchProposer {
 	forkHeights, forkMap := forks.CollectSortedForkHeights(chainCfg)
 	log.Debug("new batch proposer",
 		"maxChunkNumPerBatch", cfg.MaxChunkNumPerBatch,
@@ -75,8 +112,10 @@ func NewBatchProposer(ctx context.Context, cfg *config.BatchProposerConfig, chai
 		"gasCostIncreaseMultiplier", cfg.GasCostIncreaseMultiplier,
 		"forkHeights", forkHeights)
 
+	ctx, cancel := context.WithCancel(ctx)
 	p := &BatchProposer{
 		ctx:                             ctx,
+		cancel:                          cancel,
 		db:                              db,
 		batchOrm:                        orm.NewBatch(db),
 		chunkOrm:                        orm.NewChunk(db),
@@ -128,21 +167,81 @@ func NewBatchProposer(ctx context.Context, cfg *config.BatchProposerConfig, chai
 			Name: "rollup_propose_batch_chunks_propose_not_enough_total",
 			Help: "Total number of batch chunk propose not enough",
 		}),
+		effectiveMaxChunkNumPerBatch: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "rollup_propose_batch_effective_max_chunk_num_limit",
+			Help: "The effective maxChunkNumPerBatch used for the most recent batch proposal",
+		}),
+		effectiveMaxL1CommitGasPerBatch: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "rollup_propose_batch_effective_max_l1_commit_gas_limit",
+			Help: "The effective maxL1CommitGasPerBatch used for the most recent batch proposal",
+		}),
+		effectiveMaxBlobSize: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "rollup_propose_batch_effective_max_blob_size_limit",
+			Help: "The effective blob size limit used for the most recent batch proposal",
+		}),
+		unusedBlobBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "rollup_propose_batch_unused_blob_bytes",
+			Help: "The blob bytes left unused by the most recently proposed batch",
+		}),
+		proposeBatchSkippedBusyTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "rollup_propose_batch_skipped_busy_total",
+			Help: "Total number of propose batch invocations skipped because a previous invocation was still running",
+		}),
+	}
+
+	p.chunkSelectionMode = chunkSelectionGreedy
+	if chunkSelectionMode(cfg.ChunkSelectionMode) == chunkSelectionMaxFill {
+		p.chunkSelectionMode = chunkSelectionMaxFill
 	}
 
-	// If BanachBlock is not set in chain's genesis config, banachForkHeight is inf,
-	// which means chunk proposer uses the codecv0 version by default.
-	// TODO: Must change it to real fork name.
+	batchDebugMaxFiles := cfg.BatchDebugMaxFiles
+	if cfg.BatchDebugDir != "" && batchDebugMaxFiles == 0 {
+		// An operator who sets BatchDebugDir without BatchDebugMaxFiles almost
+		// certainly wants rotation, not unbounded trace files on disk.
+		batchDebugMaxFiles = defaultBatchDebugMaxFiles
+	}
+	p.debugSink = NewBatchDebugSink(cfg.BatchDebugDir, batchDebugMaxFiles)
+
+	// codecRegistry picks the codec (codecv0, codecv1, ...) to use for a
+	// given block. The codecv0 -> codecv1 transition is specifically the
+	// Banach fork height, which is NOT the same as forkHeights above:
+	// forkHeights also includes hardforks that don't change the batch
+	// encoding, and may sort an unrelated fork before Banach.
+	banachForkHeight := uint64(math.MaxUint64)
 	if chainCfg.BanachBlock != nil {
-		p.banachForkHeight = chainCfg.BanachBlock.Uint64()
+		banachForkHeight = chainCfg.BanachBlock.Uint64()
+	}
+	p.codecRegistry = NewCodecRegistry(banachForkHeight)
+
+	p.staticLimits = BatchSizeLimits{
+		MaxChunkNumPerBatch:    cfg.MaxChunkNumPerBatch,
+		MaxL1CommitGasPerBatch: cfg.MaxL1CommitGasPerBatch,
+		MaxBlobSize:            maxBlobSize,
+	}
+	if cfg.DynamicSizing && l1FeeOracle != nil {
+		p.sizingPolicy = newPIBatchSizingPolicy(l1FeeOracle, p.staticLimits, cfg.TargetCostPerByte, cfg.SizingKP, cfg.SizingKI, cfg.SizingMinScale)
 	} else {
-		p.banachForkHeight = math.MaxUint64
+		p.sizingPolicy = newStaticBatchSizingPolicy(p.staticLimits)
 	}
 	return p
 }
 
-// TryProposeBatch tries to propose a new batches.
+// TryProposeBatch tries to propose a new batches. If a previous invocation
+// is still running, or Stop has been called, this is a no-op.
 func (p *BatchProposer) TryProposeBatch() {
+	select {
+	case <-p.ctx.Done():
+		return
+	default:
+	}
+
+	if !p.mu.TryLock() {
+		p.proposeBatchSkippedBusyTotal.Inc()
+		log.Warn("skipping propose batch because a previous invocation is still running")
+		return
+	}
+	defer p.mu.Unlock()
+
 	p.batchProposerCircleTotal.Inc()
 	batch, err := p.proposeBatch()
 	if err != nil {
@@ -153,7 +252,7 @@ func (p *BatchProposer) TryProposeBatch() {
 	if batch == nil {
 		return
 	}
-	err = p.db.Transaction(func(dbTX *gorm.DB) error {
+	err = p.db.WithContext(p.ctx).Transaction(func(dbTX *gorm.DB) error {
 		batch, dbErr := p.batchOrm.InsertBatch(p.ctx, batch, dbTX)
 		if dbErr != nil {
 			log.Warn("BatchProposer.updateBatchInfoInDB insert batch failure",
@@ -173,14 +272,48 @@ func (p *BatchProposer) TryProposeBatch() {
 	}
 }
 
+// Stop cancels p.ctx, aborting any in-flight DB calls made by a running
+// proposeBatch, and waits for that invocation to return or for ctx to be
+// done, whichever happens first. After Stop is called, TryProposeBatch
+// becomes a permanent no-op.
+func (p *BatchProposer) Stop(ctx context.Context) error {
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.mu.Lock()
+		p.mu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (p *BatchProposer) proposeBatch() (*encoding.Batch, error) {
 	unbatchedChunkIndex, err := p.batchOrm.GetFirstUnbatchedChunkIndex(p.ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// select at most p.maxChunkNumPerBatch chunks
-	dbChunks, err := p.chunkOrm.GetChunksGEIndex(p.ctx, unbatchedChunkIndex, int(p.maxChunkNumPerBatch))
+	limits, err := p.sizingPolicy.Limits(p.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute batch size limits: %w", err)
+	}
+	p.effectiveMaxChunkNumPerBatch.Set(float64(limits.MaxChunkNumPerBatch))
+	p.effectiveMaxL1CommitGasPerBatch.Set(float64(limits.MaxL1CommitGasPerBatch))
+	p.effectiveMaxBlobSize.Set(float64(limits.MaxBlobSize))
+	log.Debug("effective batch size limits for this proposal",
+		"maxChunkNumPerBatch", limits.MaxChunkNumPerBatch,
+		"maxL1CommitGasPerBatch", limits.MaxL1CommitGasPerBatch,
+		"maxBlobSize", limits.MaxBlobSize)
+
+	// select at most limits.MaxChunkNumPerBatch chunks
+	dbChunks, err := p.chunkOrm.GetChunksGEIndex(p.ctx, unbatchedChunkIndex, int(limits.MaxChunkNumPerBatch))
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +322,12 @@ func (p *BatchProposer) proposeBatch() (*encoding.Batch, error) {
 		return nil, nil
 	}
 
-	maxChunksThisBatch := p.maxChunkNumPerBatch
+	var trace *batchTrace
+	if p.debugSink != nil {
+		trace = &batchTrace{StartChunkIndex: unbatchedChunkIndex, CandidateChunkCount: len(dbChunks)}
+	}
+
+	maxChunksThisBatch := limits.MaxChunkNumPerBatch
 	for i, chunk := range dbChunks {
 		// if a chunk is starting at a fork boundary, only consider earlier chunks
 		if i != 0 && p.forkMap[chunk.StartBlockNumber] {
@@ -197,14 +335,22 @@ func (p *BatchProposer) proposeBatch() (*encoding.Batch, error) {
 			if uint64(len(dbChunks)) < maxChunksThisBatch {
 				maxChunksThisBatch = uint64(len(dbChunks))
 			}
+			if trace != nil {
+				trace.ForkBoundaryTruncatedAt = chunk.StartBlockNumber
+				trace.CandidateChunkCount = len(dbChunks)
+			}
 			break
 		}
 	}
 
+	getDAChunksStart := time.Now()
 	daChunks, err := p.getDAChunks(dbChunks)
 	if err != nil {
 		return nil, err
 	}
+	if trace != nil {
+		trace.GetDAChunksMillis = time.Since(getDAChunksStart).Milliseconds()
+	}
 
 	parentDBBatch, err := p.batchOrm.GetLatestBatch(p.ctx)
 	if err != nil {
@@ -214,54 +360,44 @@ func (p *BatchProposer) proposeBatch() (*encoding.Batch, error) {
 	var batch encoding.Batch
 	if parentDBBatch != nil { // TODO: remove this check, return error when nil.
 		batch.Index = parentDBBatch.Index + 1
-		var parentDABatch *codecv0.DABatch
-		parentDABatch, err = codecv0.NewDABatchFromBytes(parentDBBatch.BatchHeader)
-		if err != nil {
-			return nil, err
+		// The parent batch must be decoded with whichever codec was active
+		// when it was itself proposed. That is NOT necessarily the codec
+		// active for the new batch's first chunk: at the first batch after a
+		// fork, dbChunks[0].StartBlockNumber is at or after the fork height
+		// while the parent batch predates it, so the codec must be looked up
+		// from the parent batch's own last chunk instead.
+		parentChunks, chunkErr := p.chunkOrm.GetChunksGEIndex(p.ctx, parentDBBatch.EndChunkIndex, 1)
+		if chunkErr != nil {
+			return nil, chunkErr
 		}
-		batch.TotalL1MessagePoppedBefore = parentDABatch.TotalL1MessagePopped
-		batch.ParentBatchHash = common.HexToHash(parentDBBatch.Hash)
-	}
-
-	for i, chunk := range daChunks {
-		batch.Chunks = append(batch.Chunks, chunk)
-		metrics, calcErr := p.calculateBatchMetrics(&batch)
-		if calcErr != nil {
-			return nil, fmt.Errorf("failed to calculate batch metrics: %w", calcErr)
+		if len(parentChunks) == 0 {
+			return nil, fmt.Errorf("missing chunk %d for parent batch %d", parentDBBatch.EndChunkIndex, parentDBBatch.Index)
 		}
-		totalOverEstimateL1CommitGas := uint64(p.gasCostIncreaseMultiplier * float64(metrics.l1CommitGas))
-		if metrics.l1CommitCalldataSize > p.maxL1CommitCalldataSizePerBatch ||
-			totalOverEstimateL1CommitGas > p.maxL1CommitGasPerBatch ||
-			metrics.l1CommitBlobSize > maxBlobSize {
-			if i == 0 {
-				// The first chunk exceeds hard limits, which indicates a bug in the chunk-proposer, manual fix is needed.
-				return nil, fmt.Errorf(
-					"the first chunk exceeds limits; start block number: %v, end block number: %v, limits: %+v, maxChunkNum: %v, maxL1CommitCalldataSize: %v, maxL1CommitGas: %v, maxBlobSize: %v",
-					dbChunks[0].StartBlockNumber, dbChunks[0].EndBlockNumber, metrics, p.maxChunkNumPerBatch, p.maxL1CommitCalldataSizePerBatch, p.maxL1CommitGasPerBatch, maxBlobSize)
-			}
-
-			log.Debug("breaking limit condition in batching",
-				"currentL1CommitCalldataSize", metrics.l1CommitCalldataSize,
-				"maxL1CommitCalldataSizePerBatch", p.maxL1CommitCalldataSizePerBatch,
-				"currentOverEstimateL1CommitGas", totalOverEstimateL1CommitGas,
-				"maxL1CommitGasPerBatch", p.maxL1CommitGasPerBatch)
-
-			batch.Chunks = batch.Chunks[:len(batch.Chunks)-1]
-
-			metrics, err := p.calculateBatchMetrics(&batch)
-			if err != nil {
-				return nil, fmt.Errorf("failed to calculate batch metrics: %w", err)
-			}
-
-			p.recordBatchMetrics(metrics)
-			return &batch, nil
+		parentCodec := p.codecRegistry.CodecForBlock(parentChunks[0].StartBlockNumber)
+		parentDABatch, decodeErr := parentCodec.NewDABatchFromBytes(parentDBBatch.BatchHeader)
+		if decodeErr != nil {
+			return nil, decodeErr
 		}
+		batch.TotalL1MessagePoppedBefore = parentDABatch.TotalL1MessagePoppedCount()
+		batch.ParentBatchHash = common.HexToHash(parentDBBatch.Hash)
+	}
+	if trace != nil {
+		trace.BatchIndex = batch.Index
+		trace.ParentBatchHash = batch.ParentBatchHash.Hex()
+		trace.CodecVersion = p.codecRegistry.CodecForBlock(dbChunks[0].StartBlockNumber).Name()
 	}
 
-	metrics, calcErr := p.calculateBatchMetrics(&batch)
-	if calcErr != nil {
-		return nil, fmt.Errorf("failed to calculate batch metrics: %w", calcErr)
+	metrics, limitHit, err := p.selectChunks(daChunks, dbChunks, &batch, limits, trace)
+	if err != nil {
+		return nil, err
+	}
+	if limitHit {
+		p.recordBatchMetrics(metrics)
+		p.recordUnusedBlobBytes(metrics, limits)
+		p.writeBatchTrace(trace, &batch, metrics, true, false)
+		return &batch, nil
 	}
+
 	currentTimeSec := uint64(time.Now().Unix())
 	if metrics.firstBlockTimestamp+p.batchTimeoutSec < currentTimeSec || metrics.numChunks == maxChunksThisBatch {
 		log.Info("reached maximum number of chunks in batch or first block timeout",
@@ -272,6 +408,8 @@ func (p *BatchProposer) proposeBatch() (*encoding.Batch, error) {
 
 		p.batchFirstBlockTimeoutReached.Inc()
 		p.recordBatchMetrics(metrics)
+		p.recordUnusedBlobBytes(metrics, limits)
+		p.writeBatchTrace(trace, &batch, metrics, false, metrics.firstBlockTimestamp+p.batchTimeoutSec < currentTimeSec)
 		return &batch, nil
 	}
 
@@ -280,6 +418,182 @@ func (p *BatchProposer) proposeBatch() (*encoding.Batch, error) {
 	return nil, nil
 }
 
+// writeBatchTrace finalizes trace with the outcome of this proposeBatch call
+// and persists it via p.debugSink. trace may be nil when debug tracing is
+// disabled, in which case this is a no-op.
+func (p *BatchProposer) writeBatchTrace(trace *batchTrace, batch *encoding.Batch, metrics *batchMetrics, limitHit, timeoutReached bool) {
+	if trace == nil {
+		return
+	}
+	trace.EndChunkIndex = trace.StartChunkIndex + uint64(len(batch.Chunks)) - 1
+	trace.LimitHit = limitHit
+	trace.TimeoutReached = timeoutReached
+	trace.FirstBlockTimestamp = metrics.firstBlockTimestamp
+	p.debugSink.write(trace)
+}
+
+// selectChunks picks which prefix of daChunks to include in batch, subject
+// to limits, according to p.chunkSelectionMode. It returns the metrics of
+// the resulting batch and whether selection stopped short of including all
+// of daChunks because of a limit.
+func (p *BatchProposer) selectChunks(daChunks []*encoding.Chunk, dbChunks []*orm.Chunk, batch *encoding.Batch, limits BatchSizeLimits, trace *batchTrace) (*batchMetrics, bool, error) {
+	if p.chunkSelectionMode == chunkSelectionMaxFill {
+		return p.selectChunksMaxFill(daChunks, dbChunks, batch, limits, trace)
+	}
+	return p.selectChunksGreedy(daChunks, dbChunks, batch, limits, trace)
+}
+
+// calculateBatchMetricsTraced wraps calculateBatchMetrics, additionally
+// timing the call and appending a prefixMetricsSample to trace when tracing
+// is enabled. trace may be nil.
+func (p *BatchProposer) calculateBatchMetricsTraced(batch *encoding.Batch, trace *batchTrace) (*batchMetrics, error) {
+	start := time.Now()
+	metrics, err := p.calculateBatchMetrics(batch)
+	if trace == nil {
+		return metrics, err
+	}
+	trace.CalculateMetricsMillis += time.Since(start).Milliseconds()
+	if err == nil {
+		trace.PrefixMetrics = append(trace.PrefixMetrics, prefixMetricsSample{
+			NumChunks:            metrics.numChunks,
+			L1CommitCalldataSize: metrics.l1CommitCalldataSize,
+			L1CommitGas:          metrics.l1CommitGas,
+			L1CommitBlobSize:     metrics.l1CommitBlobSize,
+		})
+	}
+	return metrics, err
+}
+
+// selectChunksGreedy appends chunks one at a time and stops on the first
+// limit breach, as proposeBatch has always done.
+func (p *BatchProposer) selectChunksGreedy(daChunks []*encoding.Chunk, dbChunks []*orm.Chunk, batch *encoding.Batch, limits BatchSizeLimits, trace *batchTrace) (*batchMetrics, bool, error) {
+	for i, chunk := range daChunks {
+		batch.Chunks = append(batch.Chunks, chunk)
+		metrics, err := p.calculateBatchMetricsTraced(batch, trace)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to calculate batch metrics: %w", err)
+		}
+		if !p.exceedsLimits(batch, metrics, limits) {
+			continue
+		}
+		if i == 0 {
+			// The first chunk exceeds hard limits, which indicates a bug in the chunk-proposer, manual fix is needed.
+			return nil, false, p.firstChunkExceedsLimitsErr(dbChunks[0], metrics, limits)
+		}
+
+		log.Debug("breaking limit condition in batching",
+			"currentL1CommitCalldataSize", metrics.l1CommitCalldataSize,
+			"maxL1CommitCalldataSizePerBatch", p.maxL1CommitCalldataSizePerBatch,
+			"currentL1CommitBlobSize", metrics.l1CommitBlobSize,
+			"maxBlobSize", limits.MaxBlobSize)
+
+		batch.Chunks = batch.Chunks[:len(batch.Chunks)-1]
+		metrics, err = p.calculateBatchMetricsTraced(batch, trace)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to calculate batch metrics: %w", err)
+		}
+		return metrics, true, nil
+	}
+
+	metrics, err := p.calculateBatchMetricsTraced(batch, trace)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to calculate batch metrics: %w", err)
+	}
+	return metrics, false, nil
+}
+
+// largestFittingPrefix returns the largest k in [1, n] for which fits(k)
+// reports true, scanning from n down to 1 and stopping at the first fit. It
+// assumes fits is monotonic in k (if k fits, every smaller k also fits),
+// which holds for batch size limits since a shorter chunk prefix can only
+// use less calldata/gas/blob space. It returns k == 0 if no k in [1, n]
+// fits, or n == 0.
+func largestFittingPrefix(n int, fits func(k int) (bool, error)) (int, error) {
+	for k := n; k >= 1; k-- {
+		ok, err := fits(k)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return k, nil
+		}
+	}
+	return 0, nil
+}
+
+// selectChunksMaxFill evaluates candidate prefix lengths k from the longest
+// down and picks the largest one whose metrics still fit all limits, to avoid leaving
+// blob/calldata headroom unused the way selectChunksGreedy can near fork
+// boundaries. Batches must reference a contiguous chunk range, so the
+// optimal selection reduces to choosing the best prefix rather than an
+// arbitrary subset.
+func (p *BatchProposer) selectChunksMaxFill(daChunks []*encoding.Chunk, dbChunks []*orm.Chunk, batch *encoding.Batch, limits BatchSizeLimits, trace *batchTrace) (*batchMetrics, bool, error) {
+	var lastMetrics *batchMetrics
+	k, fitErr := largestFittingPrefix(len(daChunks), func(k int) (bool, error) {
+		batch.Chunks = daChunks[:k]
+		metrics, err := p.calculateBatchMetricsTraced(batch, trace)
+		if err != nil {
+			return false, fmt.Errorf("failed to calculate batch metrics: %w", err)
+		}
+		lastMetrics = metrics
+		return !p.exceedsLimits(batch, metrics, limits), nil
+	})
+	if fitErr != nil {
+		return nil, false, fitErr
+	}
+	if k > 0 {
+		batch.Chunks = daChunks[:k]
+		return lastMetrics, k < len(daChunks), nil
+	}
+
+	batch.Chunks = daChunks[:1]
+	metrics, err := p.calculateBatchMetrics(batch)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to calculate batch metrics: %w", err)
+	}
+	// The first chunk alone exceeds hard limits, which indicates a bug in
+	// the chunk-proposer, manual fix is needed.
+	return nil, false, p.firstChunkExceedsLimitsErr(dbChunks[0], metrics, limits)
+}
+
+// exceedsLimits reports whether metrics breaches any of limits, further
+// narrowed by any hard cap the batch's own codec imposes (e.g. the usable
+// EIP-4844 blob payload size, which differs by codec version).
+func (p *BatchProposer) exceedsLimits(batch *encoding.Batch, metrics *batchMetrics, limits BatchSizeLimits) bool {
+	codec := p.codecRegistry.CodecForBlock(batch.Chunks[0].Blocks[0].Header.Number.Uint64())
+
+	maxBlobSize := limits.MaxBlobSize
+	if codecMax := codec.MaxBlobSize(); codecMax > 0 && codecMax < maxBlobSize {
+		maxBlobSize = codecMax
+	}
+	maxCalldataSize := p.maxL1CommitCalldataSizePerBatch
+	if codecMax := codec.MaxCalldataSize(); codecMax > 0 && codecMax < maxCalldataSize {
+		maxCalldataSize = codecMax
+	}
+
+	totalOverEstimateL1CommitGas := uint64(p.gasCostIncreaseMultiplier * float64(metrics.l1CommitGas))
+	return metrics.l1CommitCalldataSize > maxCalldataSize ||
+		totalOverEstimateL1CommitGas > limits.MaxL1CommitGasPerBatch ||
+		metrics.l1CommitBlobSize > maxBlobSize
+}
+
+func (p *BatchProposer) firstChunkExceedsLimitsErr(firstChunk *orm.Chunk, metrics *batchMetrics, limits BatchSizeLimits) error {
+	return fmt.Errorf(
+		"the first chunk exceeds limits; start block number: %v, end block number: %v, limits: %+v, maxChunkNum: %v, maxL1CommitCalldataSize: %v, maxL1CommitGas: %v, maxBlobSize: %v",
+		firstChunk.StartBlockNumber, firstChunk.EndBlockNumber, metrics, limits.MaxChunkNumPerBatch, p.maxL1CommitCalldataSizePerBatch, limits.MaxL1CommitGasPerBatch, limits.MaxBlobSize)
+}
+
+// recordUnusedBlobBytes records how much of the effective blob size limit
+// was left unused by the proposed batch, so operators can verify that
+// chunkSelectionMaxFill is actually improving packing over greedy.
+func (p *BatchProposer) recordUnusedBlobBytes(metrics *batchMetrics, limits BatchSizeLimits) {
+	if limits.MaxBlobSize <= metrics.l1CommitBlobSize {
+		p.unusedBlobBytes.Set(0)
+		return
+	}
+	p.unusedBlobBytes.Set(float64(limits.MaxBlobSize - metrics.l1CommitBlobSize))
+}
+
 func (p *BatchProposer) getDAChunks(dbChunks []*orm.Chunk) ([]*encoding.Chunk, error) {
 	chunks := make([]*encoding.Chunk, len(dbChunks))
 	for i, c := range dbChunks {
@@ -308,20 +622,19 @@ func (p *BatchProposer) calculateBatchMetrics(batch *encoding.Batch) (*batchMetr
 	metrics.numChunks = uint64(len(batch.Chunks))
 	metrics.firstBlockTimestamp = batch.Chunks[0].Blocks[0].Header.Time
 	firstBlockNum := batch.Chunks[0].Blocks[0].Header.Number.Uint64()
-	if firstBlockNum >= p.banachForkHeight { // codecv1
-		metrics.l1CommitBlobSize, err = codecv1.EstimateBatchL1CommitBlobSize(batch)
-		if err != nil {
-			return metrics, fmt.Errorf("failed to estimate chunk L1 commit blob size: %w", err)
-		}
-	} else { // codecv0
-		metrics.l1CommitGas, err = codecv0.EstimateBatchL1CommitGas(batch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to estimate batch L1 commit gas: %w", err)
-		}
-		metrics.l1CommitCalldataSize, err = codecv0.EstimateBatchL1CommitCalldataSize(batch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to estimate batch L1 commit calldata size: %w", err)
-		}
+
+	codec := p.codecRegistry.CodecForBlock(firstBlockNum)
+	metrics.l1CommitGas, err = codec.EstimateBatchL1CommitGas(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate batch L1 commit gas: %w", err)
+	}
+	metrics.l1CommitCalldataSize, err = codec.EstimateBatchL1CommitCalldataSize(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate batch L1 commit calldata size: %w", err)
+	}
+	metrics.l1CommitBlobSize, err = codec.EstimateBatchL1CommitBlobSize(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate batch L1 commit blob size: %w", err)
 	}
 	return metrics, nil
 }
@@ -0,0 +1,95 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestBatchProposerForConcurrency returns a BatchProposer with just the
+// fields TryProposeBatch/Stop touch before reaching the DB, for testing the
+// re-entrancy guard and shutdown handshake in isolation.
+func newTestBatchProposerForConcurrency(t *testing.T) *BatchProposer {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	p := &BatchProposer{
+		proposeBatchSkippedBusyTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{Name: "test_skipped_busy"}),
+		batchProposerCircleTotal:     promauto.With(reg).NewCounter(prometheus.CounterOpts{Name: "test_circle"}),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
+}
+
+func TestTryProposeBatchSkipsWhenBusy(t *testing.T) {
+	p := newTestBatchProposerForConcurrency(t)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.TryProposeBatch()
+
+	if got := testutil.ToFloat64(p.proposeBatchSkippedBusyTotal); got != 1 {
+		t.Errorf("proposeBatchSkippedBusyTotal = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(p.batchProposerCircleTotal); got != 0 {
+		t.Errorf("batchProposerCircleTotal = %v, want 0 when skipped as busy", got)
+	}
+}
+
+func TestTryProposeBatchNoopAfterStop(t *testing.T) {
+	p := newTestBatchProposerForConcurrency(t)
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	p.TryProposeBatch()
+
+	if got := testutil.ToFloat64(p.batchProposerCircleTotal); got != 0 {
+		t.Errorf("batchProposerCircleTotal = %v, want 0 after Stop", got)
+	}
+	if got := testutil.ToFloat64(p.proposeBatchSkippedBusyTotal); got != 0 {
+		t.Errorf("proposeBatchSkippedBusyTotal = %v, want 0 after Stop", got)
+	}
+}
+
+func TestStopWaitsForInFlightProposal(t *testing.T) {
+	p := newTestBatchProposerForConcurrency(t)
+
+	// Simulate an in-flight TryProposeBatch holding the re-entrancy lock.
+	p.mu.Lock()
+
+	if p.mu.TryLock() {
+		t.Fatal("TryLock succeeded while a proposal was in flight")
+	}
+
+	stopDone := make(chan error, 1)
+	go func() { stopDone <- p.Stop(context.Background()) }()
+
+	select {
+	case <-stopDone:
+		t.Fatal("Stop returned before the in-flight proposal released the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	select {
+	case <-p.ctx.Done():
+	default:
+		t.Fatal("Stop did not cancel p.ctx immediately")
+	}
+
+	p.mu.Unlock() // simulate the in-flight TryProposeBatch finishing
+
+	select {
+	case err := <-stopDone:
+		if err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return after the lock was released")
+	}
+}
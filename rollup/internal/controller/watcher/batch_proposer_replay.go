@@ -0,0 +1,183 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/log"
+
+	"scroll-tech/common/types/encoding"
+
+	"scroll-tech/rollup/internal/orm"
+)
+
+// ReplayMismatch reports a historical batch whose chunk range the proposer
+// would not reproduce if it ran today.
+type ReplayMismatch struct {
+	BatchIndex         uint64
+	ExpectedStartChunk uint64
+	ExpectedEndChunk   uint64
+	ProducedStartChunk uint64
+	ProducedEndChunk   uint64
+	ExpectedMetrics    *batchMetrics
+	ProducedMetrics    *batchMetrics
+}
+
+// ReplayReport summarizes a Replay run over a range of historical batches.
+type ReplayReport struct {
+	BatchesChecked uint64
+	Mismatches     []ReplayMismatch
+}
+
+// Replay re-runs chunk selection against each already-committed batch in
+// [fromIndex, toIndex], using the chunks starting at that batch's original
+// first chunk and the codec/parent linkage in effect at the time, and asserts that the
+// proposer would today choose the same chunk boundaries. This catches
+// unintended regressions in calculateBatchMetrics or codec estimators
+// before they reach mainnet, without a live DB-writing run. It is exposed
+// as a CLI subcommand on the rollup binary so codec upgrades can be
+// regression-tested against the full batch history.
+func (p *BatchProposer) Replay(ctx context.Context, fromIndex, toIndex uint64) (*ReplayReport, error) {
+	report := &ReplayReport{}
+	for index := fromIndex; index <= toIndex; index++ {
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		default:
+		}
+
+		expected, err := p.batchOrm.GetBatchByIndex(ctx, index)
+		if err != nil {
+			return report, fmt.Errorf("failed to load historical batch %d: %w", index, err)
+		}
+		if expected == nil {
+			continue
+		}
+		report.BatchesChecked++
+
+		mismatch, err := p.replayOne(ctx, expected)
+		if err != nil {
+			return report, fmt.Errorf("failed to replay batch %d: %w", index, err)
+		}
+		if mismatch != nil {
+			log.Warn("replay: batch boundary mismatch",
+				"batchIndex", index,
+				"expectedStartChunk", mismatch.ExpectedStartChunk, "expectedEndChunk", mismatch.ExpectedEndChunk,
+				"producedStartChunk", mismatch.ProducedStartChunk, "producedEndChunk", mismatch.ProducedEndChunk)
+			report.Mismatches = append(report.Mismatches, *mismatch)
+		}
+	}
+
+	log.Info("replay finished", "batchesChecked", report.BatchesChecked, "mismatches", len(report.Mismatches))
+	return report, nil
+}
+
+// replayOne re-derives the chunk range the proposer would pick starting at
+// expected's first chunk, and compares it against expected. It returns a
+// non-nil ReplayMismatch if the two disagree.
+//
+// replayOne always uses p.staticLimits, never p.sizingPolicy.Limits: when
+// DynamicSizing is enabled, the live PI-controller limits depend on the L1
+// fee sample observed at the moment Replay runs, so two back-to-back runs
+// over the same range could report different mismatches purely from L1 fee
+// jitter. Replay is a regression test against history and must be
+// deterministic.
+func (p *BatchProposer) replayOne(ctx context.Context, expected *orm.Batch) (*ReplayMismatch, error) {
+	limits := p.staticLimits
+
+	dbChunks, err := p.chunkOrm.GetChunksGEIndex(ctx, expected.StartChunkIndex, int(limits.MaxChunkNumPerBatch))
+	if err != nil {
+		return nil, err
+	}
+	if len(dbChunks) == 0 {
+		return nil, fmt.Errorf("no chunks found starting at index %d", expected.StartChunkIndex)
+	}
+
+	for i, chunk := range dbChunks {
+		// if a chunk is starting at a fork boundary, only consider earlier chunks
+		if i != 0 && p.forkMap[chunk.StartBlockNumber] {
+			dbChunks = dbChunks[:i]
+			break
+		}
+	}
+
+	daChunks, err := p.getDAChunks(dbChunks)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch encoding.Batch
+	batch.Index = expected.Index
+	if expected.Index > 0 {
+		parentDBBatch, parentErr := p.batchOrm.GetBatchByIndex(ctx, expected.Index-1)
+		if parentErr != nil {
+			return nil, fmt.Errorf("failed to load parent batch %d: %w", expected.Index-1, parentErr)
+		}
+		if parentDBBatch == nil {
+			return nil, fmt.Errorf("missing parent batch %d", expected.Index-1)
+		}
+		// See the matching comment in proposeBatch: the parent batch must be
+		// decoded with the codec active at its own last chunk, not the new
+		// batch's first chunk, which can be on the other side of a fork.
+		parentChunks, chunkErr := p.chunkOrm.GetChunksGEIndex(ctx, parentDBBatch.EndChunkIndex, 1)
+		if chunkErr != nil {
+			return nil, chunkErr
+		}
+		if len(parentChunks) == 0 {
+			return nil, fmt.Errorf("missing chunk %d for parent batch %d", parentDBBatch.EndChunkIndex, parentDBBatch.Index)
+		}
+		parentCodec := p.codecRegistry.CodecForBlock(parentChunks[0].StartBlockNumber)
+		parentDABatch, decodeErr := parentCodec.NewDABatchFromBytes(parentDBBatch.BatchHeader)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		batch.TotalL1MessagePoppedBefore = parentDABatch.TotalL1MessagePoppedCount()
+		batch.ParentBatchHash = common.HexToHash(parentDBBatch.Hash)
+	}
+
+	producedMetrics, _, err := p.selectChunks(daChunks, dbChunks, &batch, limits, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	producedStart := expected.StartChunkIndex
+	producedEnd := producedStart + producedMetrics.numChunks - 1
+	if !replayMismatches(producedEnd, expected.EndChunkIndex) {
+		return nil, nil
+	}
+
+	expectedChunkCount := expected.EndChunkIndex - expected.StartChunkIndex + 1
+	expectedMetrics, err := p.calculateBatchMetrics(&encoding.Batch{
+		Index:                      batch.Index,
+		ParentBatchHash:            batch.ParentBatchHash,
+		TotalL1MessagePoppedBefore: batch.TotalL1MessagePoppedBefore,
+		Chunks:                     daChunks[:expectedChunkCount],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReplayMismatch(expected, producedStart, producedEnd, expectedMetrics, producedMetrics), nil
+}
+
+// replayMismatches reports whether the chunk range the proposer would pick
+// today (producedEnd) disagrees with the historical batch (expectedEnd).
+func replayMismatches(producedEnd, expectedEnd uint64) bool {
+	return producedEnd != expectedEnd
+}
+
+// buildReplayMismatch assembles a ReplayMismatch describing the disagreement
+// between a historical batch (expected) and the chunk range/metrics the
+// proposer would pick today (producedStart, producedEnd, producedMetrics).
+func buildReplayMismatch(expected *orm.Batch, producedStart, producedEnd uint64, expectedMetrics, producedMetrics *batchMetrics) *ReplayMismatch {
+	return &ReplayMismatch{
+		BatchIndex:         expected.Index,
+		ExpectedStartChunk: expected.StartChunkIndex,
+		ExpectedEndChunk:   expected.EndChunkIndex,
+		ProducedStartChunk: producedStart,
+		ProducedEndChunk:   producedEnd,
+		ExpectedMetrics:    expectedMetrics,
+		ProducedMetrics:    producedMetrics,
+	}
+}
@@ -0,0 +1,39 @@
+package watcher
+
+import (
+	"testing"
+
+	"scroll-tech/rollup/internal/orm"
+)
+
+func TestReplayMatch(t *testing.T) {
+	if replayMismatches(10, 10) {
+		t.Error("replayMismatches(10, 10) = true, want false when produced and expected agree")
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	if !replayMismatches(11, 10) {
+		t.Error("replayMismatches(11, 10) = false, want true when produced and expected disagree")
+	}
+
+	expected := &orm.Batch{Index: 7, StartChunkIndex: 5, EndChunkIndex: 10}
+	expectedMetrics := &batchMetrics{numChunks: 6}
+	producedMetrics := &batchMetrics{numChunks: 7}
+
+	mismatch := buildReplayMismatch(expected, 5, 11, expectedMetrics, producedMetrics)
+
+	if mismatch.BatchIndex != expected.Index {
+		t.Errorf("BatchIndex = %d, want %d", mismatch.BatchIndex, expected.Index)
+	}
+	if mismatch.ExpectedStartChunk != expected.StartChunkIndex || mismatch.ExpectedEndChunk != expected.EndChunkIndex {
+		t.Errorf("expected chunk range = [%d, %d], want [%d, %d]",
+			mismatch.ExpectedStartChunk, mismatch.ExpectedEndChunk, expected.StartChunkIndex, expected.EndChunkIndex)
+	}
+	if mismatch.ProducedStartChunk != 5 || mismatch.ProducedEndChunk != 11 {
+		t.Errorf("produced chunk range = [%d, %d], want [5, 11]", mismatch.ProducedStartChunk, mismatch.ProducedEndChunk)
+	}
+	if mismatch.ExpectedMetrics != expectedMetrics || mismatch.ProducedMetrics != producedMetrics {
+		t.Error("buildReplayMismatch did not carry through the given metrics pointers")
+	}
+}
@@ -0,0 +1,50 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLargestFittingPrefixAllFit(t *testing.T) {
+	got, err := largestFittingPrefix(5, func(k int) (bool, error) { return true, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 5 {
+		t.Errorf("largestFittingPrefix() = %d, want 5 when every k fits", got)
+	}
+}
+
+func TestLargestFittingPrefixNoneFit(t *testing.T) {
+	got, err := largestFittingPrefix(5, func(k int) (bool, error) { return false, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 0 {
+		t.Errorf("largestFittingPrefix() = %d, want 0 when no k fits", got)
+	}
+}
+
+func TestLargestFittingPrefixExactFit(t *testing.T) {
+	const want = 3
+	got, err := largestFittingPrefix(5, func(k int) (bool, error) { return k <= want, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Errorf("largestFittingPrefix() = %d, want %d", got, want)
+	}
+}
+
+func TestLargestFittingPrefixPropagatesError(t *testing.T) {
+	wantErr := errors.New("boom")
+	_, err := largestFittingPrefix(5, func(k int) (bool, error) {
+		if k == 4 {
+			return false, wantErr
+		}
+		return false, nil
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("largestFittingPrefix() error = %v, want %v", err, wantErr)
+	}
+}
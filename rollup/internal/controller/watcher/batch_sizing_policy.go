@@ -0,0 +1,164 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/scroll-tech/go-ethereum/log"
+)
+
+// BatchSizeLimits holds the limits applied when selecting chunks for a
+// single batch proposal.
+type BatchSizeLimits struct {
+	MaxChunkNumPerBatch    uint64
+	MaxL1CommitGasPerBatch uint64
+	MaxBlobSize            uint64
+}
+
+// BatchSizingPolicy computes the effective BatchSizeLimits to apply to the
+// next proposeBatch call. Implementations may simply echo the configured
+// static limits, or derive tighter/looser limits from observed L1
+// conditions.
+type BatchSizingPolicy interface {
+	// Limits returns the limits to use for the next batch proposal.
+	Limits(ctx context.Context) (BatchSizeLimits, error)
+}
+
+// staticBatchSizingPolicy always returns the configured limits unchanged.
+// It is the default policy and preserves today's behavior.
+type staticBatchSizingPolicy struct {
+	limits BatchSizeLimits
+}
+
+func newStaticBatchSizingPolicy(limits BatchSizeLimits) BatchSizingPolicy {
+	return &staticBatchSizingPolicy{limits: limits}
+}
+
+func (s *staticBatchSizingPolicy) Limits(_ context.Context) (BatchSizeLimits, error) {
+	return s.limits, nil
+}
+
+// defaultSizingMinScale floors the PI controller's scale factor when
+// newPIBatchSizingPolicy is constructed with a non-positive minScale, e.g. an
+// operator enabling DynamicSizing without also setting SizingMinScale. Without
+// this floor, a real L1 fee spike can drive scale to 0 and every scaled limit
+// with it, so the very next batch fails its first chunk and proposeBatch
+// halts production entirely.
+const defaultSizingMinScale = 0.1
+
+// L1FeeOracle reports the L1 fee conditions used to adapt batch size limits.
+// It is satisfied by a thin wrapper around the L1 RPC client already used by
+// the sender package.
+type L1FeeOracle interface {
+	// SuggestL1BaseFee returns the current L1 base fee, in wei.
+	SuggestL1BaseFee(ctx context.Context) (uint64, error)
+	// SuggestL1BlobBaseFee returns the current L1 blob base fee, in wei.
+	SuggestL1BlobBaseFee(ctx context.Context) (uint64, error)
+}
+
+// piBatchSizingPolicy scales staticLimits towards a target L1 cost-per-byte
+// using a simple PI controller fed by recent L1 base fee / blob base fee
+// samples. When L1 is cheap the effective limits are scaled up towards
+// staticLimits (never beyond it) so batches fill up more; when L1 is
+// expensive the limits are scaled down so batches are submitted smaller and
+// more often, smoothing out cost spikes.
+type piBatchSizingPolicy struct {
+	oracle       L1FeeOracle
+	staticLimits BatchSizeLimits
+
+	targetCostPerByte float64
+	kP, kI            float64
+	minScale          float64
+
+	mu       sync.Mutex
+	integral float64
+}
+
+func newPIBatchSizingPolicy(oracle L1FeeOracle, staticLimits BatchSizeLimits, targetCostPerByte, kP, kI, minScale float64) BatchSizingPolicy {
+	if minScale <= 0 {
+		minScale = defaultSizingMinScale
+	}
+	return &piBatchSizingPolicy{
+		oracle:            oracle,
+		staticLimits:      staticLimits,
+		targetCostPerByte: targetCostPerByte,
+		kP:                kP,
+		kI:                kI,
+		minScale:          minScale,
+	}
+}
+
+// Limits samples the current L1 base fee and blob base fee, derives an
+// observed cost-per-byte, and scales staticLimits by a factor in
+// [minScale, 1] to keep the observed cost-per-byte close to
+// targetCostPerByte.
+func (p *piBatchSizingPolicy) Limits(ctx context.Context) (BatchSizeLimits, error) {
+	baseFee, err := p.oracle.SuggestL1BaseFee(ctx)
+	if err != nil {
+		return BatchSizeLimits{}, fmt.Errorf("failed to sample L1 base fee: %w", err)
+	}
+	blobBaseFee, err := p.oracle.SuggestL1BlobBaseFee(ctx)
+	if err != nil {
+		return BatchSizeLimits{}, fmt.Errorf("failed to sample L1 blob base fee: %w", err)
+	}
+
+	// Blob data costs blobBaseFee per unit of blob gas, and one byte of
+	// usable blob payload costs roughly one unit of blob gas; calldata costs
+	// 16 gas/byte (non-zero byte) priced at baseFee. We use whichever is the
+	// larger contributor as the observed cost-per-byte signal, since that is
+	// the constraint currently driving batch cost.
+	blobCostPerByte := float64(blobBaseFee)
+	calldataCostPerByte := float64(baseFee) * 16
+	observedCostPerByte := blobCostPerByte
+	if calldataCostPerByte > observedCostPerByte {
+		observedCostPerByte = calldataCostPerByte
+	}
+
+	p.mu.Lock()
+	errTerm := observedCostPerByte - p.targetCostPerByte
+	p.integral += errTerm
+	scale := 1 - (p.kP*errTerm + p.kI*p.integral)
+	if scale > 1 {
+		scale = 1
+		// anti-windup: don't let the integral push scale further past 1.
+		p.integral -= errTerm
+	} else if scale < p.minScale {
+		scale = p.minScale
+		p.integral -= errTerm
+	}
+	p.mu.Unlock()
+
+	// Round up rather than truncate: scale is bounded away from 0 by minScale
+	// above, so as long as a staticLimits field is itself non-zero, every
+	// scaled field below should stay non-zero too and let a batch make
+	// progress, rather than silently truncating a small-but-positive product
+	// to 0 and leaving the next proposal to fail on its first chunk.
+	limits := BatchSizeLimits{
+		MaxChunkNumPerBatch:    uint64(math.Ceil(scale * float64(p.staticLimits.MaxChunkNumPerBatch))),
+		MaxL1CommitGasPerBatch: uint64(math.Ceil(scale * float64(p.staticLimits.MaxL1CommitGasPerBatch))),
+		MaxBlobSize:            uint64(math.Ceil(scale * float64(p.staticLimits.MaxBlobSize))),
+	}
+	if limits.MaxChunkNumPerBatch == 0 {
+		limits.MaxChunkNumPerBatch = 1
+	}
+	if limits.MaxL1CommitGasPerBatch == 0 {
+		limits.MaxL1CommitGasPerBatch = 1
+	}
+	if limits.MaxBlobSize == 0 {
+		limits.MaxBlobSize = 1
+	}
+
+	log.Debug("adaptive batch sizing policy decision",
+		"l1BaseFee", baseFee,
+		"l1BlobBaseFee", blobBaseFee,
+		"observedCostPerByte", observedCostPerByte,
+		"targetCostPerByte", p.targetCostPerByte,
+		"scale", scale,
+		"effectiveMaxChunkNumPerBatch", limits.MaxChunkNumPerBatch,
+		"effectiveMaxL1CommitGasPerBatch", limits.MaxL1CommitGasPerBatch,
+		"effectiveMaxBlobSize", limits.MaxBlobSize)
+
+	return limits, nil
+}
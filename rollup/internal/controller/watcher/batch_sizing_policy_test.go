@@ -0,0 +1,68 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeL1FeeOracle returns fixed fee values, for exercising piBatchSizingPolicy
+// without a real L1 client.
+type fakeL1FeeOracle struct {
+	baseFee, blobBaseFee uint64
+}
+
+func (f *fakeL1FeeOracle) SuggestL1BaseFee(_ context.Context) (uint64, error) {
+	return f.baseFee, nil
+}
+
+func (f *fakeL1FeeOracle) SuggestL1BlobBaseFee(_ context.Context) (uint64, error) {
+	return f.blobBaseFee, nil
+}
+
+func TestPIBatchSizingPolicyFloorsZeroMinScale(t *testing.T) {
+	staticLimits := BatchSizeLimits{MaxChunkNumPerBatch: 100, MaxL1CommitGasPerBatch: 1_000_000, MaxBlobSize: 1_000_000}
+	// A huge observed cost relative to target drives scale as low as the
+	// controller allows; an operator who left SizingMinScale unset (0) should
+	// still get a usable batch, not limits scaled down to a sliver of the
+	// static configuration.
+	oracle := &fakeL1FeeOracle{baseFee: 1_000_000, blobBaseFee: 1_000_000}
+	policy := newPIBatchSizingPolicy(oracle, staticLimits, 1, 1, 1, 0)
+
+	limits, err := policy.Limits(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantMin := uint64(defaultSizingMinScale * 100) // scale is floored to defaultSizingMinScale
+	if limits.MaxChunkNumPerBatch < wantMin {
+		t.Errorf("MaxChunkNumPerBatch = %d, want at least %d", limits.MaxChunkNumPerBatch, wantMin)
+	}
+	if limits.MaxL1CommitGasPerBatch < wantMin*10_000 {
+		t.Errorf("MaxL1CommitGasPerBatch = %d, want at least %d", limits.MaxL1CommitGasPerBatch, wantMin*10_000)
+	}
+	if limits.MaxBlobSize < wantMin*10_000 {
+		t.Errorf("MaxBlobSize = %d, want at least %d", limits.MaxBlobSize, wantMin*10_000)
+	}
+}
+
+func TestPIBatchSizingPolicyRoundsUpSmallStaticLimits(t *testing.T) {
+	// staticLimits small enough that scale * limit is a small positive
+	// fraction; truncating (rather than rounding up) would silently collapse
+	// these to 0 even though minScale itself is sane.
+	staticLimits := BatchSizeLimits{MaxChunkNumPerBatch: 5, MaxL1CommitGasPerBatch: 5, MaxBlobSize: 5}
+	oracle := &fakeL1FeeOracle{baseFee: 1_000_000, blobBaseFee: 1_000_000}
+	policy := newPIBatchSizingPolicy(oracle, staticLimits, 1, 1, 1, 0.1)
+
+	limits, err := policy.Limits(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits.MaxChunkNumPerBatch == 0 {
+		t.Error("MaxChunkNumPerBatch = 0, want a non-zero floor")
+	}
+	if limits.MaxL1CommitGasPerBatch == 0 {
+		t.Error("MaxL1CommitGasPerBatch = 0, want a non-zero floor")
+	}
+	if limits.MaxBlobSize == 0 {
+		t.Error("MaxBlobSize = 0, want a non-zero floor")
+	}
+}
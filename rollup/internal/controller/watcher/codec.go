@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"scroll-tech/common/types/encoding"
+	"scroll-tech/common/types/encoding/codecv0"
+	"scroll-tech/common/types/encoding/codecv1"
+)
+
+// DABatch is the common surface of the codec-specific on-chain batch header
+// types (codecv0.DABatch, codecv1.DABatch, ...) that the proposer needs,
+// independent of codec version.
+type DABatch interface {
+	// TotalL1MessagePoppedCount returns the cumulative number of L1 messages
+	// popped up to and including this batch.
+	TotalL1MessagePoppedCount() uint64
+}
+
+// Codec abstracts the L1 commit cost estimation and DA-batch decoding logic
+// that differs between codec versions, so that proposeBatch does not need to
+// branch on fork height itself. Each fork that changes the batch encoding
+// (codecv0, codecv1, ...) provides its own implementation.
+type Codec interface {
+	// EstimateBatchL1CommitGas estimates the L1 commit gas cost of batch.
+	EstimateBatchL1CommitGas(batch *encoding.Batch) (uint64, error)
+	// EstimateBatchL1CommitCalldataSize estimates the calldata size of batch's L1 commit transaction.
+	EstimateBatchL1CommitCalldataSize(batch *encoding.Batch) (uint64, error)
+	// EstimateBatchL1CommitBlobSize estimates the blob payload size of batch's L1 commit transaction.
+	EstimateBatchL1CommitBlobSize(batch *encoding.Batch) (uint64, error)
+	// NewDABatchFromBytes decodes a previously committed batch header encoded with this codec.
+	NewDABatchFromBytes(data []byte) (DABatch, error)
+	// MaxBlobSize is the maximum usable EIP-4844 blob payload size for this codec, 0 if unused.
+	MaxBlobSize() uint64
+	// MaxCalldataSize is the maximum calldata payload size for this codec's L1
+	// commit transaction, or 0 if this codec imposes no additional cap beyond
+	// the operator-configured maxL1CommitCalldataSizePerBatch.
+	MaxCalldataSize() uint64
+	// Name identifies the codec version, e.g. "codecv0", for logging and debug traces.
+	Name() string
+}
+
+// CodecRegistry selects the Codec implementation applicable to a given L2
+// block number, based on the codec version activation heights (e.g. the
+// chain's Banach fork block for the codecv0 -> codecv1 transition). These
+// are NOT the same as forks.CollectSortedForkHeights, which also includes
+// hardforks that don't change the batch encoding at all; using that list
+// here would pick the wrong codec for any such fork that activates before
+// the real codec transition.
+type CodecRegistry struct {
+	// forkHeights is sorted ascending; codecs[i+1] applies starting at
+	// forkHeights[i], codecs[0] applies before the first fork.
+	forkHeights []uint64
+	codecs      []Codec
+}
+
+// NewCodecRegistry builds a CodecRegistry that switches from codecv0 to
+// codecv1 at codecv1ActivationHeight. Pass math.MaxUint64 if codecv1 never
+// activates on this chain.
+func NewCodecRegistry(codecv1ActivationHeight uint64) *CodecRegistry {
+	return &CodecRegistry{
+		forkHeights: []uint64{codecv1ActivationHeight},
+		codecs:      []Codec{codecv0Codec{}, codecv1Codec{}},
+	}
+}
+
+// CodecForBlock returns the Codec applicable to blockNumber.
+func (r *CodecRegistry) CodecForBlock(blockNumber uint64) Codec {
+	codec := r.codecs[0]
+	for i, height := range r.forkHeights {
+		if blockNumber >= height {
+			codec = r.codecs[i+1]
+		}
+	}
+	return codec
+}
+
+type codecv0DABatch struct{ *codecv0.DABatch }
+
+func (b codecv0DABatch) TotalL1MessagePoppedCount() uint64 { return b.TotalL1MessagePopped }
+
+// codecv0Codec implements Codec for the pre-Banach calldata-only batch format.
+type codecv0Codec struct{}
+
+func (codecv0Codec) EstimateBatchL1CommitGas(batch *encoding.Batch) (uint64, error) {
+	return codecv0.EstimateBatchL1CommitGas(batch)
+}
+
+func (codecv0Codec) EstimateBatchL1CommitCalldataSize(batch *encoding.Batch) (uint64, error) {
+	return codecv0.EstimateBatchL1CommitCalldataSize(batch)
+}
+
+func (codecv0Codec) EstimateBatchL1CommitBlobSize(*encoding.Batch) (uint64, error) {
+	// codecv0 batches are committed via calldata; they have no blob payload.
+	return 0, nil
+}
+
+func (codecv0Codec) NewDABatchFromBytes(data []byte) (DABatch, error) {
+	daBatch, err := codecv0.NewDABatchFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return codecv0DABatch{daBatch}, nil
+}
+
+func (codecv0Codec) MaxBlobSize() uint64 { return 0 }
+
+// MaxCalldataSize returns 0: codecv0 imposes no additional cap beyond the
+// operator-configured maxL1CommitCalldataSizePerBatch.
+func (codecv0Codec) MaxCalldataSize() uint64 { return 0 }
+
+func (codecv0Codec) Name() string { return "codecv0" }
+
+type codecv1DABatch struct{ *codecv1.DABatch }
+
+func (b codecv1DABatch) TotalL1MessagePoppedCount() uint64 { return b.TotalL1MessagePopped }
+
+// codecv1Codec implements Codec for the post-Banach EIP-4844 blob batch format.
+type codecv1Codec struct{}
+
+func (codecv1Codec) EstimateBatchL1CommitGas(*encoding.Batch) (uint64, error) {
+	// codecv1 batches are committed via blob; L1 commit gas is dominated by
+	// the blob fee, which is not accounted for in execution gas.
+	return 0, nil
+}
+
+func (codecv1Codec) EstimateBatchL1CommitCalldataSize(*encoding.Batch) (uint64, error) {
+	// codecv1 batches carry their payload in a blob, not calldata.
+	return 0, nil
+}
+
+func (codecv1Codec) EstimateBatchL1CommitBlobSize(batch *encoding.Batch) (uint64, error) {
+	return codecv1.EstimateBatchL1CommitBlobSize(batch)
+}
+
+func (codecv1Codec) NewDABatchFromBytes(data []byte) (DABatch, error) {
+	daBatch, err := codecv1.NewDABatchFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return codecv1DABatch{daBatch}, nil
+}
+
+func (codecv1Codec) MaxBlobSize() uint64 { return maxBlobSize }
+
+// MaxCalldataSize returns 0: codecv1 batches carry no calldata payload, so
+// EstimateBatchL1CommitCalldataSize is always 0 and no cap is meaningful.
+func (codecv1Codec) MaxCalldataSize() uint64 { return 0 }
+
+func (codecv1Codec) Name() string { return "codecv1" }
@@ -0,0 +1,35 @@
+package watcher
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCodecRegistryCodecForBlock(t *testing.T) {
+	const banachBlock = uint64(100)
+	registry := NewCodecRegistry(banachBlock)
+
+	tests := []struct {
+		name        string
+		blockNumber uint64
+		wantName    string
+	}{
+		{"before fork", banachBlock - 1, "codecv0"},
+		{"at fork", banachBlock, "codecv1"},
+		{"after fork", banachBlock + 1, "codecv1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.CodecForBlock(tt.blockNumber).Name(); got != tt.wantName {
+				t.Errorf("CodecForBlock(%d) = %q, want %q", tt.blockNumber, got, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestCodecRegistryNoCodecv1Activation(t *testing.T) {
+	registry := NewCodecRegistry(math.MaxUint64)
+	if got := registry.CodecForBlock(math.MaxUint64 - 1).Name(); got != "codecv0" {
+		t.Errorf("CodecForBlock() = %q, want codecv0 when codecv1 never activates", got)
+	}
+}
@@ -0,0 +1,43 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/ethclient"
+)
+
+// ethClientL1FeeOracle implements L1FeeOracle by sampling the current L1
+// base fee and blob base fee directly from an L1 RPC client, the same client
+// used elsewhere to submit L1 commit transactions.
+type ethClientL1FeeOracle struct {
+	client *ethclient.Client
+}
+
+// NewEthClientL1FeeOracle returns an L1FeeOracle backed by client. Pass the
+// resulting oracle to NewBatchProposer to enable piBatchSizingPolicy via
+// cfg.DynamicSizing.
+func NewEthClientL1FeeOracle(client *ethclient.Client) L1FeeOracle {
+	return &ethClientL1FeeOracle{client: client}
+}
+
+// SuggestL1BaseFee returns the current L1 base fee, in wei.
+func (o *ethClientL1FeeOracle) SuggestL1BaseFee(ctx context.Context) (uint64, error) {
+	header, err := o.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch L1 header: %w", err)
+	}
+	if header.BaseFee == nil {
+		return 0, fmt.Errorf("L1 header %d has no base fee", header.Number)
+	}
+	return header.BaseFee.Uint64(), nil
+}
+
+// SuggestL1BlobBaseFee returns the current L1 blob base fee, in wei.
+func (o *ethClientL1FeeOracle) SuggestL1BlobBaseFee(ctx context.Context) (uint64, error) {
+	blobBaseFee, err := o.client.BlobBaseFee(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch L1 blob base fee: %w", err)
+	}
+	return blobBaseFee.Uint64(), nil
+}